@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/getlantern/systray"
+	"github.com/pkg/errors"
+)
+
+// customMenuItemSpec is a single entry declared in the --config file. It
+// drives an extra systray.MenuItem that runs a shell command either
+// periodically (BitBar/AnyBar plugin style, its stdout becoming the title)
+// or in response to timer events, or both.
+type customMenuItemSpec struct {
+	Title          string   `json:"title" toml:"title"`
+	Cmd            string   `json:"cmd" toml:"cmd"`
+	On             []string `json:"on" toml:"on"`
+	UpdateInterval string   `json:"update_interval" toml:"update_interval"`
+	Timeout        string   `json:"timeout" toml:"timeout"`
+}
+
+type userConfig struct {
+	Items []customMenuItemSpec `json:"items" toml:"items"`
+}
+
+func loadUserConfig(path string) (*userConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading config file")
+	}
+
+	cfg := &userConfig{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		if err := toml.Unmarshal(data, cfg); err != nil {
+			return nil, errors.Wrap(err, "parsing toml config")
+		}
+	default:
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, errors.Wrap(err, "parsing json config")
+		}
+	}
+
+	return cfg, nil
+}
+
+// customMenuItem pairs a declared spec with its live systray entry and the
+// timer events it should react to.
+type customMenuItem struct {
+	spec     customMenuItemSpec
+	menuItem *systray.MenuItem
+	events   chan timerEvent
+	timeout  time.Duration
+	interval time.Duration
+	busy     chan struct{} // size-1 semaphore: a token present means free
+}
+
+func newCustomMenuItems(cfg *userConfig) []*customMenuItem {
+	items := make([]*customMenuItem, 0, len(cfg.Items))
+
+	for _, spec := range cfg.Items {
+		busy := make(chan struct{}, 1)
+		busy <- struct{}{}
+
+		items = append(items, &customMenuItem{
+			spec:     spec,
+			menuItem: systray.AddMenuItem(spec.Title, spec.Cmd),
+			events:   make(chan timerEvent, 4),
+			timeout:  parseConfigDuration(spec.Timeout, 10*time.Second),
+			interval: parseConfigDuration(spec.UpdateInterval, 0),
+			busy:     busy,
+		})
+	}
+
+	return items
+}
+
+func parseConfigDuration(val string, fallback time.Duration) time.Duration {
+	if val == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return fallback
+	}
+
+	return d
+}
+
+// runCustomMenuItem drives one config-declared menu item for the lifetime of
+// the app: it refreshes the title on a timer (if configured) and runs the
+// command whenever one of its declared events fires.
+func runCustomMenuItem(item *customMenuItem) {
+	var tick <-chan time.Time
+	if item.interval > 0 {
+		ticker := time.NewTicker(item.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		runCustomCommand(item, true)
+	}
+
+	for {
+		select {
+		case <-tick:
+			runCustomCommand(item, true)
+		case event := <-item.events:
+			if eventMatches(item.spec.On, event) {
+				runCustomCommand(item, false)
+			}
+		case <-item.menuItem.ClickedCh:
+			if matchesOn(item.spec.On, "clicked") {
+				runCustomCommand(item, false)
+			}
+		}
+	}
+}
+
+func eventMatches(on []string, event timerEvent) bool {
+	switch event {
+	case timerWorkDoneEvent, timerBreakDoneEvent:
+		return matchesOn(on, "timer_out")
+	case timerPausedEvent:
+		return matchesOn(on, "paused")
+	case timerStartedEvent:
+		return matchesOn(on, "started")
+	default:
+		return false
+	}
+}
+
+func matchesOn(on []string, name string) bool {
+	for _, v := range on {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runCustomCommand runs the item's shell command in the background, honoring
+// the configured timeout and skipping the run entirely if a previous one is
+// still in flight. updateTitle controls whether stdout replaces the menu
+// title (the BitBar-style periodic refresh) or is ignored (event triggers,
+// which just run the command as a side effect).
+func runCustomCommand(item *customMenuItem, updateTitle bool) {
+	select {
+	case <-item.busy:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { item.busy <- struct{}{} }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), item.timeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, "sh", "-c", item.spec.Cmd).Output()
+		if err != nil {
+			item.menuItem.SetTooltip(fmt.Sprintf("%s: %s", item.spec.Cmd, err))
+			return
+		}
+
+		item.menuItem.SetTooltip(item.spec.Cmd)
+
+		if updateTitle {
+			if title := strings.TrimSpace(string(out)); title != "" {
+				item.menuItem.SetTitle(title)
+			}
+		}
+	}()
+}
+
+// dispatchEvents forwards every event from src to each dest, dropping it for
+// a destination whose buffer is full rather than blocking the timer loop.
+func dispatchEvents(src <-chan timerEvent, dests []chan timerEvent) {
+	for event := range src {
+		for _, dest := range dests {
+			select {
+			case dest <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eiannone/keyboard"
+	"github.com/getlantern/systray"
+	"github.com/pkg/errors"
+)
+
+// keyBindings maps the configured key characters to timer commands for the
+// terminal keyboard listener below.
+type keyBindings struct {
+	stop    rune
+	cont    rune
+	restart rune
+	quit    rune
+}
+
+func parseKeyBindings(stopStr, continueStr, restartStr, quitStr string) (keyBindings, error) {
+	parseKey := func(val string) (rune, error) {
+		runes := []rune(val)
+		if len(runes) != 1 {
+			return 0, errors.Errorf("key binding %q must be a single character", val)
+		}
+
+		return runes[0], nil
+	}
+
+	stop, err := parseKey(stopStr)
+	if err != nil {
+		return keyBindings{}, err
+	}
+
+	cont, err := parseKey(continueStr)
+	if err != nil {
+		return keyBindings{}, err
+	}
+
+	restart, err := parseKey(restartStr)
+	if err != nil {
+		return keyBindings{}, err
+	}
+
+	quit, err := parseKey(quitStr)
+	if err != nil {
+		return keyBindings{}, err
+	}
+
+	return keyBindings{stop: stop, cont: cont, restart: restart, quit: quit}, nil
+}
+
+// terminalKeyboardLoop listens for key presses on vtimer's own controlling
+// terminal and feeds them into the same commands channel the menu clicks
+// use, so the two are interchangeable. This is deliberately not a global
+// hotkey: github.com/eiannone/keyboard reads raw stdin on the process's
+// attached terminal, not an OS-level hook, so these keys only work while
+// that terminal has focus; they will not fire while another window (or the
+// tray itself) is focused. It shuts down cleanly once ctx is cancelled.
+func terminalKeyboardLoop(ctx context.Context, bindings keyBindings, commands chan<- timerCommand) {
+	events, err := keyboard.GetKeys(4)
+	if err != nil {
+		fmt.Println("terminal keyboard shortcuts disabled (no attached terminal):", err)
+		return
+	}
+	defer keyboard.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if event.Err != nil {
+				return
+			}
+
+			switch event.Rune {
+			case bindings.stop:
+				commands <- timerStopCommand
+			case bindings.cont:
+				commands <- timerContinueCommand
+			case bindings.restart:
+				commands <- timerRestartCommand
+			case bindings.quit:
+				systray.Quit()
+				return
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -8,18 +9,11 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/gen2brain/beeep"
 	"github.com/getlantern/systray"
 	"github.com/pkg/errors"
-)
 
-type timer struct {
-	display  displayFn
-	interval time.Duration
-	commands chan timerCommand
-	events   chan timerEvent
-	symbols  timerSymbolFn
-}
+	"github.com/vkuznetsov/vtimer/sessionlog"
+)
 
 type displayFn func(diff time.Duration) string
 type timerCommand int
@@ -31,10 +25,14 @@ const (
 	timerStopCommand timerCommand = iota
 	timerContinueCommand
 	timerRestartCommand
+	timerSkipCommand
+	timerResetCommand
 )
 
 const (
-	timerOutEvent timerEvent = iota
+	timerWorkDoneEvent timerEvent = iota
+	timerBreakDoneEvent
+	timerInterruptedEvent
 	timerPausedEvent
 	timerStartedEvent
 )
@@ -45,106 +43,27 @@ const (
 	timerRestartSymbol
 )
 
+var cancelTimer context.CancelFunc
+
 func main() {
 	systray.Run(onReady, onExit)
 }
 
-func timerLoop(timer *timer) {
-	var restInterval time.Duration
-	var diff time.Duration
-
-	started := true
-	now := time.Now()
-	stopTime := now.Add(timer.interval)
-
-	timer.events <- timerStartedEvent
-
-	for {
-		now = time.Now()
-
-		select {
-		case cmd := <-timer.commands:
-			switch cmd {
-			case timerStopCommand:
-				restInterval = stopTime.Sub(now)
-				systray.SetTitle(timer.symbols(timerStopSymbol) + " " + timer.display(diff))
-				timer.events <- timerPausedEvent
-				started = false
-			case timerContinueCommand:
-				stopTime = now.Add(restInterval)
-				timer.events <- timerStartedEvent
-				started = true
-			case timerRestartCommand:
-				stopTime = now.Add(timer.interval)
-				timer.events <- timerStartedEvent
-				started = true
-			}
-		default:
-		}
-
-		if started {
-			diff = stopTime.Sub(now)
-
-			if diff > 0 {
-				systray.SetTitle(timer.symbols(timerContinueSymbol) + " " + timer.display(diff))
-			} else {
-				started = false
-				systray.SetTitle(timer.symbols(timerStopSymbol) + " " + timer.display(timer.interval))
-				timer.events <- timerOutEvent
-				notifyTimeout(timer)
-			}
-		}
-
-		time.Sleep(time.Second)
-	}
-}
-
-type menu struct {
-	restartMenuItem  *systray.MenuItem
-	stopMenuItem     *systray.MenuItem
-	continueMenuItem *systray.MenuItem
-	statsMenuItem    *systray.MenuItem
-	quitMenuItem     *systray.MenuItem
-}
-
-func menuLoop(menu *menu, timerEvents chan timerEvent, timerCommands chan timerCommand) {
-	intervalCounter := 0
-
-	for {
-		menu.statsMenuItem.SetTitle(fmt.Sprintf("%d intervals passed", intervalCounter))
-
-		select {
-		case timerEvent := <-timerEvents:
-			switch timerEvent {
-			case timerOutEvent:
-				menu.continueMenuItem.Disable()
-				menu.stopMenuItem.Disable()
-				intervalCounter++
-			case timerStartedEvent:
-				menu.stopMenuItem.Enable()
-				menu.continueMenuItem.Disable()
-			case timerPausedEvent:
-				menu.stopMenuItem.Disable()
-				menu.continueMenuItem.Enable()
-			}
-		case <-menu.quitMenuItem.ClickedCh:
-			systray.Quit()
-		case <-menu.statsMenuItem.ClickedCh:
-			intervalCounter = 0
-		case <-menu.restartMenuItem.ClickedCh:
-			timerCommands <- timerRestartCommand
-		case <-menu.stopMenuItem.ClickedCh:
-			timerCommands <- timerStopCommand
-		case <-menu.continueMenuItem.ClickedCh:
-			timerCommands <- timerContinueCommand
-		}
-	}
-}
-
 func onReady() {
 	symbolsStr := flag.String("state-symbols", "○□▷", `Symbols for timer state and actions: restart, stop, continue`)
 	noSymbols := flag.Bool("no-state-symbols", false, `Do not use symbols for timer state and actions`)
-	intervalStr := flag.String("interval", "25m", `Timer interval. Ex: "25m", "1h5m14s". Supported units - h, m, s`)
+	workStr := flag.String("work", "25m", `Work interval. Ex: "25m", "1h5m14s". Supported units - h, m, s`)
+	shortStr := flag.String("short", "5m", `Short break interval. Ex: "5m"`)
+	longStr := flag.String("long", "15m", `Long break interval, taken every --cycles work sessions. Ex: "15m"`)
+	cycles := flag.Int("cycles", 4, `Number of work sessions before a long break`)
+	configPath := flag.String("config", "", `Path to a JSON or TOML config file declaring extra menu items`)
+	keyStop := flag.String("term-key-stop", "s", `Key that sends the stop command while vtimer's own terminal has focus (not a global hotkey)`)
+	keyContinue := flag.String("term-key-continue", "c", `Key that sends the continue command while vtimer's own terminal has focus (not a global hotkey)`)
+	keyRestart := flag.String("term-key-restart", "r", `Key that sends the restart command while vtimer's own terminal has focus (not a global hotkey)`)
+	keyQuit := flag.String("term-key-quit", "q", `Key that quits the app while vtimer's own terminal has focus (not a global hotkey)`)
+	logFile := flag.String("log-file", defaultLogFile(), `Path to the session log. Set to "" to disable logging`)
+	tag := flag.String("tag", "", `Tag recorded against every session in the log`)
+	tickStr := flag.String("tick", "1s", `How often the remaining time display is refreshed. Ex: "1s", "500ms"`)
 	displayStr := flag.String("display", "ms", `Units for display remaining time. Supported values: `+
 		`"h" - hours only; `+
 		`"hm" - hours and minutes; `+
@@ -154,7 +73,17 @@ func onReady() {
 
 	flag.Parse()
 
-	interval, err := parseInterval(*intervalStr)
+	workInterval, err := parseInterval(*workStr)
+	if err != nil {
+		showHelpAndExit(err)
+	}
+
+	shortInterval, err := parseInterval(*shortStr)
+	if err != nil {
+		showHelpAndExit(err)
+	}
+
+	longInterval, err := parseInterval(*longStr)
 	if err != nil {
 		showHelpAndExit(err)
 	}
@@ -169,26 +98,108 @@ func onReady() {
 		showHelpAndExit(err)
 	}
 
-	systray.SetTooltip(fmt.Sprintf("Timer set %s", *intervalStr))
-	restartMenuItem := systray.AddMenuItem(symbols(timerRestartSymbol)+" Restart", "Restart timer")
+	bindings, err := parseKeyBindings(*keyStop, *keyContinue, *keyRestart, *keyQuit)
+	if err != nil {
+		showHelpAndExit(err)
+	}
+
+	tick, err := time.ParseDuration(*tickStr)
+	if err != nil {
+		showHelpAndExit(err)
+	}
+	if tick <= 0 {
+		showHelpAndExit(errors.New("invalid --tick: must be a positive duration"))
+	}
+
+	var log *sessionlog.Log
+	var todayTotals sessionlog.Totals
+	if *logFile != "" {
+		log = sessionlog.New(*logFile)
+
+		sessions, err := log.Load()
+		if err != nil {
+			showHelpAndExit(err)
+		}
+
+		todayTotals = sessions.FilterByDate(time.Now()).Totals()
+	}
+
+	systray.SetTooltip(fmt.Sprintf("Work %s / short break %s / long break %s", *workStr, *shortStr, *longStr))
+	restartMenuItem := systray.AddMenuItem(symbols(timerRestartSymbol)+" Restart", "Restart current phase")
 	stopMenuItem := systray.AddMenuItem(symbols(timerStopSymbol)+" Stop", "Stop timer")
 	continueMenuItem := systray.AddMenuItem(symbols(timerContinueSymbol)+" Continue", "Continue stopped timer")
+	skipMenuItem := systray.AddMenuItem("Skip", "Skip the current phase")
+	resetMenuItem := systray.AddMenuItem("Reset cycle", "Reset the pomodoro cycle back to Work 1")
+	statsMenuItem := systray.AddMenuItem(todayStatsTitle(todayTotals), "Reset stats (only affects today's tally, not the session log)")
+	weeklyStatsMenuItem := systray.AddMenuItem("Show stats", "Show this week's breakdown")
+
+	var customItems []*customMenuItem
+	if *configPath != "" {
+		cfg, err := loadUserConfig(*configPath)
+		if err != nil {
+			showHelpAndExit(err)
+		}
+
+		systray.AddSeparator()
+		customItems = newCustomMenuItems(cfg)
+	}
+
 	systray.AddSeparator()
-	statsMenuItem := systray.AddMenuItem("", "Reset stats")
 	quitMenuItem := systray.AddMenuItem("Quit", "Quit the whole app")
 
-	menu := &menu{restartMenuItem, stopMenuItem, continueMenuItem, statsMenuItem, quitMenuItem}
+	if log == nil {
+		weeklyStatsMenuItem.Disable()
+	}
+
+	menu := &menu{
+		restartMenuItem:     restartMenuItem,
+		stopMenuItem:        stopMenuItem,
+		continueMenuItem:    continueMenuItem,
+		skipMenuItem:        skipMenuItem,
+		resetMenuItem:       resetMenuItem,
+		statsMenuItem:       statsMenuItem,
+		weeklyStatsMenuItem: weeklyStatsMenuItem,
+		quitMenuItem:        quitMenuItem,
+	}
+
+	timerCommands := make(chan timerCommand, 4)
+	timerEvents := make(chan timerEvent, 4)
+	timer := &timer{
+		workInterval:  workInterval,
+		shortInterval: shortInterval,
+		longInterval:  longInterval,
+		cycles:        *cycles,
+		display:       display,
+		commands:      timerCommands,
+		events:        timerEvents,
+		symbols:       symbols,
+		log:           log,
+		tag:           *tag,
+		tick:          tick,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelTimer = cancel
 
-	timerCommands := make(chan timerCommand)
-	timerEvents := make(chan timerEvent)
-	timer := &timer{interval: interval, display: display, commands: timerCommands, events: timerEvents, symbols: symbols}
+	menuEvents := make(chan timerEvent, 8)
+	subscribers := []chan timerEvent{menuEvents}
+	for _, item := range customItems {
+		subscribers = append(subscribers, item.events)
+	}
 
-	go menuLoop(menu, timerEvents, timerCommands)
-	go timerLoop(timer)
+	go dispatchEvents(timerEvents, subscribers)
+	go menuLoop(menu, timer, menuEvents, todayTotals)
+	for _, item := range customItems {
+		go runCustomMenuItem(item)
+	}
+	go terminalKeyboardLoop(ctx, bindings, timerCommands)
+	go timerLoop(ctx, timer)
 }
 
 func onExit() {
-	// clean up here
+	if cancelTimer != nil {
+		cancelTimer()
+	}
 }
 
 func parseInterval(val string) (time.Duration, error) {
@@ -299,10 +310,3 @@ func showHelpAndExit(err error) {
 	fmt.Printf("Try %s --help\n", os.Args[0])
 	systray.Quit()
 }
-
-func notifyTimeout(timer *timer) {
-	msg := fmt.Sprintf("%s have passed", timer.display(timer.interval))
-	if err := beeep.Notify("Time out", msg, ""); err != nil {
-		panic(err)
-	}
-}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"time"
+
+	"github.com/getlantern/systray"
+
+	"github.com/vkuznetsov/vtimer/sessionlog"
+)
+
+type menu struct {
+	restartMenuItem     *systray.MenuItem
+	stopMenuItem        *systray.MenuItem
+	continueMenuItem    *systray.MenuItem
+	skipMenuItem        *systray.MenuItem
+	resetMenuItem       *systray.MenuItem
+	statsMenuItem       *systray.MenuItem
+	weeklyStatsMenuItem *systray.MenuItem
+	quitMenuItem        *systray.MenuItem
+}
+
+func menuLoop(menu *menu, t *timer, events <-chan timerEvent, initialTotals sessionlog.Totals) {
+	menu.statsMenuItem.SetTitle(todayStatsTitle(initialTotals))
+
+	// resetAt is the baseline "Reset stats" filters today's totals against:
+	// only sessions that started at or after this instant count. It starts at the
+	// zero time so nothing is excluded until the user actually clicks reset.
+	var resetAt time.Time
+
+	for {
+		select {
+		case timerEvent := <-events:
+			switch timerEvent {
+			case timerWorkDoneEvent:
+				menu.continueMenuItem.Disable()
+				menu.stopMenuItem.Disable()
+				menu.statsMenuItem.SetTitle(todayStatsTitle(todayTotalsSince(t.log, resetAt)))
+			case timerBreakDoneEvent:
+				menu.continueMenuItem.Disable()
+				menu.stopMenuItem.Disable()
+				menu.statsMenuItem.SetTitle(todayStatsTitle(todayTotalsSince(t.log, resetAt)))
+			case timerInterruptedEvent:
+				menu.statsMenuItem.SetTitle(todayStatsTitle(todayTotalsSince(t.log, resetAt)))
+			case timerStartedEvent:
+				menu.stopMenuItem.Enable()
+				menu.continueMenuItem.Disable()
+			case timerPausedEvent:
+				menu.stopMenuItem.Disable()
+				menu.continueMenuItem.Enable()
+			}
+		case <-menu.quitMenuItem.ClickedCh:
+			systray.Quit()
+		case <-menu.statsMenuItem.ClickedCh:
+			resetAt = time.Now()
+			menu.statsMenuItem.SetTitle(todayStatsTitle(todayTotalsSince(t.log, resetAt)))
+		case <-menu.weeklyStatsMenuItem.ClickedCh:
+			showWeeklyStats(t.log)
+		case <-menu.restartMenuItem.ClickedCh:
+			t.commands <- timerRestartCommand
+		case <-menu.stopMenuItem.ClickedCh:
+			t.commands <- timerStopCommand
+		case <-menu.continueMenuItem.ClickedCh:
+			t.commands <- timerContinueCommand
+		case <-menu.skipMenuItem.ClickedCh:
+			t.commands <- timerSkipCommand
+		case <-menu.resetMenuItem.ClickedCh:
+			t.commands <- timerResetCommand
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gen2brain/beeep"
+	"github.com/getlantern/systray"
+
+	"github.com/vkuznetsov/vtimer/sessionlog"
+)
+
+type timerPhase int
+
+const (
+	workPhase timerPhase = iota
+	shortBreakPhase
+	longBreakPhase
+)
+
+func (p timerPhase) String() string {
+	switch p {
+	case workPhase:
+		return "Work"
+	case shortBreakPhase:
+		return "Short Break"
+	case longBreakPhase:
+		return "Long Break"
+	default:
+		return "Unknown"
+	}
+}
+
+type timer struct {
+	display       displayFn
+	workInterval  time.Duration
+	shortInterval time.Duration
+	longInterval  time.Duration
+	cycles        int
+	commands      chan timerCommand
+	events        chan timerEvent
+	symbols       timerSymbolFn
+
+	log  *sessionlog.Log
+	tag  string
+	tick time.Duration
+
+	phase      timerPhase
+	cycleCount int
+}
+
+// upcomingPhase reports the phase that follows the one currently running,
+// without mutating any state. It mirrors the transition logic in doWork.
+func (t *timer) upcomingPhase() timerPhase {
+	if t.phase != workPhase {
+		return workPhase
+	}
+
+	if t.cycleCount+1 >= t.cycles {
+		return longBreakPhase
+	}
+
+	return shortBreakPhase
+}
+
+// stateFn is one phase of the pomodoro cycle. It runs until the phase ends
+// and returns the state function for the next phase, or nil once ctx is
+// cancelled.
+type stateFn func(ctx context.Context, t *timer) stateFn
+
+// phaseResult describes how a phase ended.
+type phaseResult int
+
+const (
+	phaseCompleted phaseResult = iota
+	phaseSkipped
+	phaseReset
+	phaseCancelled
+)
+
+func timerLoop(ctx context.Context, t *timer) {
+	state := doWork
+
+	for state != nil {
+		state = state(ctx, t)
+	}
+}
+
+func doWork(ctx context.Context, t *timer) stateFn {
+	t.phase = workPhase
+
+	switch runPhase(ctx, t, t.workInterval) {
+	case phaseCancelled:
+		return nil
+	case phaseReset:
+		t.cycleCount = 0
+		return doWork
+	}
+
+	if t.cycleCount+1 >= t.cycles {
+		t.cycleCount = 0
+		return longBreak
+	}
+
+	t.cycleCount++
+	return shortBreak
+}
+
+func shortBreak(ctx context.Context, t *timer) stateFn {
+	t.phase = shortBreakPhase
+
+	switch runPhase(ctx, t, t.shortInterval) {
+	case phaseCancelled:
+		return nil
+	case phaseReset:
+		t.cycleCount = 0
+		return doWork
+	}
+
+	return doWork
+}
+
+func longBreak(ctx context.Context, t *timer) stateFn {
+	t.phase = longBreakPhase
+
+	switch runPhase(ctx, t, t.longInterval) {
+	case phaseCancelled:
+		return nil
+	case phaseReset:
+		t.cycleCount = 0
+		return doWork
+	}
+
+	return doWork
+}
+
+// runPhase drives the countdown for a single phase, handling pause/continue,
+// skip and reset commands along the way. It returns how the phase ended.
+//
+// The countdown is driven by a ticker rather than a sleep loop: a blocking
+// select over the ticker, the command channel and ctx.Done() gives a stable
+// display cadence and lets systray.Quit (via ctx cancellation) stop this
+// goroutine immediately instead of waiting out a pending sleep.
+func runPhase(ctx context.Context, t *timer, interval time.Duration) phaseResult {
+	var restInterval time.Duration
+	var diff time.Duration
+
+	started := true
+	now := time.Now()
+	phaseStart := now
+	stopTime := now.Add(interval)
+
+	ticker := time.NewTicker(t.tick)
+	defer ticker.Stop()
+
+	t.events <- timerStartedEvent
+
+	for {
+		select {
+		case <-ctx.Done():
+			return phaseCancelled
+		case cmd := <-t.commands:
+			now = time.Now()
+			switch cmd {
+			case timerStopCommand:
+				restInterval = stopTime.Sub(now)
+				systray.SetTitle(t.symbols(timerStopSymbol) + " " + t.phase.String() + " " + t.display(diff))
+				t.events <- timerPausedEvent
+				started = false
+			case timerContinueCommand:
+				stopTime = now.Add(restInterval)
+				t.events <- timerStartedEvent
+				started = true
+			case timerRestartCommand:
+				stopTime = now.Add(interval)
+				t.events <- timerStartedEvent
+				started = true
+			case timerSkipCommand:
+				logSession(t, phaseStart, now)
+				t.events <- timerInterruptedEvent
+				return phaseSkipped
+			case timerResetCommand:
+				logSession(t, phaseStart, now)
+				t.events <- timerInterruptedEvent
+				return phaseReset
+			}
+		case now = <-ticker.C:
+			if !started {
+				continue
+			}
+
+			diff = stopTime.Sub(now)
+
+			if diff > 0 {
+				systray.SetTitle(t.symbols(timerContinueSymbol) + " " + t.phase.String() + " " + t.display(diff))
+				continue
+			}
+
+			started = false
+			systray.SetTitle(t.symbols(timerStopSymbol) + " " + t.phase.String() + " " + t.display(interval))
+			notifyTimeout(t, interval)
+			logSession(t, phaseStart, now)
+
+			if t.phase == workPhase {
+				t.events <- timerWorkDoneEvent
+			} else {
+				t.events <- timerBreakDoneEvent
+			}
+
+			return phaseCompleted
+		}
+	}
+}
+
+// logSession records a just-finished or skipped phase to t.log, if one is
+// configured.
+func logSession(t *timer, start, end time.Time) {
+	if t.log == nil {
+		return
+	}
+
+	phase := "break"
+	if t.phase == workPhase {
+		phase = "work"
+	}
+
+	session := sessionlog.Session{Start: start, End: end, Phase: phase, Tag: t.tag}
+	if err := t.log.Append(session); err != nil {
+		fmt.Println("session log:", err)
+	}
+}
+
+func notifyTimeout(t *timer, justFinished time.Duration) {
+	msg := fmt.Sprintf("%s have passed. Next up: %s", t.display(justFinished), t.upcomingPhase())
+	if err := beeep.Notify(t.phase.String()+" done", msg, ""); err != nil {
+		panic(err)
+	}
+}
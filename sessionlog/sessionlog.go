@@ -0,0 +1,185 @@
+// Package sessionlog records completed and interrupted pomodoro sessions to
+// a plain-text, append-only log file (timer.txt-style), independent of the
+// systray UI so it can be loaded, written and tested on its own.
+package sessionlog
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Session is a single work or break interval.
+type Session struct {
+	Start time.Time
+	End   time.Time
+	Phase string // "work" or "break"
+	Tag   string // optional; vtimer sets it only from the --tag flag
+}
+
+// Duration returns how long the session lasted.
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Sessions is a list of sessions with helpers for slicing and summarizing it.
+type Sessions []Session
+
+// FilterByDate returns the sessions that started on the given date, compared
+// in date's own location.
+func (ss Sessions) FilterByDate(date time.Time) Sessions {
+	year, month, day := date.Date()
+
+	var filtered Sessions
+	for _, s := range ss {
+		y, m, d := s.Start.In(date.Location()).Date()
+		if y == year && m == month && d == day {
+			filtered = append(filtered, s)
+		}
+	}
+
+	return filtered
+}
+
+// Totals summarizes a set of sessions: count and total duration per phase.
+type Totals struct {
+	WorkCount     int
+	WorkDuration  time.Duration
+	BreakCount    int
+	BreakDuration time.Duration
+}
+
+// Totals summarizes the sessions by phase.
+func (ss Sessions) Totals() Totals {
+	var t Totals
+
+	for _, s := range ss {
+		switch s.Phase {
+		case "work":
+			t.WorkCount++
+			t.WorkDuration += s.Duration()
+		default:
+			t.BreakCount++
+			t.BreakDuration += s.Duration()
+		}
+	}
+
+	return t
+}
+
+// Log is an append-only session log backed by a single text file.
+type Log struct {
+	path string
+}
+
+// New returns a Log backed by the file at path. The file and its parent
+// directory are created lazily on the first Append.
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// Load reads every session recorded so far. A missing file is not an error;
+// it simply yields no sessions.
+func (l *Log) Load() (Sessions, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "opening session log")
+	}
+	defer f.Close()
+
+	var sessions Sessions
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		session, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading session log")
+	}
+
+	return sessions, nil
+}
+
+// Append records a single session, creating the log file and its parent
+// directory if needed.
+func (l *Log) Append(s Session) error {
+	if dir := filepath.Dir(l.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errors.Wrap(err, "creating session log directory")
+		}
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "opening session log")
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, formatLine(s)); err != nil {
+		return errors.Wrap(err, "writing session log")
+	}
+
+	return nil
+}
+
+// Path returns the file path backing the log.
+func (l *Log) Path() string {
+	return l.path
+}
+
+func formatLine(s Session) string {
+	tag := s.Tag
+	if tag == "" {
+		tag = "-"
+	}
+
+	return strings.Join([]string{
+		s.Start.Format(time.RFC3339),
+		s.End.Format(time.RFC3339),
+		s.Phase,
+		tag,
+	}, "\t")
+}
+
+func parseLine(line string) (Session, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 4 {
+		return Session{}, errors.Errorf("invalid session log line: %q", line)
+	}
+
+	start, err := time.Parse(time.RFC3339, fields[0])
+	if err != nil {
+		return Session{}, errors.Wrap(err, "parsing session start time")
+	}
+
+	end, err := time.Parse(time.RFC3339, fields[1])
+	if err != nil {
+		return Session{}, errors.Wrap(err, "parsing session end time")
+	}
+
+	tag := fields[3]
+	if tag == "-" {
+		tag = ""
+	}
+
+	return Session{Start: start, End: end, Phase: fields[2], Tag: tag}, nil
+}
@@ -0,0 +1,91 @@
+package sessionlog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timer.txt")
+	log := New(path)
+
+	start := time.Date(2026, 7, 25, 9, 0, 0, 0, time.UTC)
+
+	sessions := Sessions{
+		{Start: start, End: start.Add(25 * time.Minute), Phase: "work", Tag: "deepwork"},
+		{Start: start.Add(25 * time.Minute), End: start.Add(30 * time.Minute), Phase: "break"},
+	}
+
+	for _, s := range sessions {
+		if err := log.Append(s); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	loaded, err := log.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(loaded) != len(sessions) {
+		t.Fatalf("Load() returned %d sessions, want %d", len(loaded), len(sessions))
+	}
+
+	if !loaded[0].Start.Equal(sessions[0].Start) || loaded[0].Tag != "deepwork" {
+		t.Errorf("Load()[0] = %+v, want %+v", loaded[0], sessions[0])
+	}
+
+	if loaded[1].Tag != "" {
+		t.Errorf("Load()[1].Tag = %q, want empty", loaded[1].Tag)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	log := New(filepath.Join(t.TempDir(), "does-not-exist.txt"))
+
+	sessions, err := log.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if sessions != nil {
+		t.Errorf("Load() = %v, want nil", sessions)
+	}
+}
+
+func TestFilterByDate(t *testing.T) {
+	day1 := time.Date(2026, 7, 24, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 7, 25, 10, 0, 0, 0, time.UTC)
+
+	sessions := Sessions{
+		{Start: day1, End: day1.Add(25 * time.Minute), Phase: "work"},
+		{Start: day2, End: day2.Add(25 * time.Minute), Phase: "work"},
+		{Start: day2.Add(time.Hour), End: day2.Add(time.Hour + 5*time.Minute), Phase: "break"},
+	}
+
+	filtered := sessions.FilterByDate(day2)
+	if len(filtered) != 2 {
+		t.Fatalf("FilterByDate() returned %d sessions, want 2", len(filtered))
+	}
+}
+
+func TestTotals(t *testing.T) {
+	start := time.Date(2026, 7, 25, 9, 0, 0, 0, time.UTC)
+
+	sessions := Sessions{
+		{Start: start, End: start.Add(25 * time.Minute), Phase: "work"},
+		{Start: start.Add(25 * time.Minute), End: start.Add(50 * time.Minute), Phase: "work"},
+		{Start: start.Add(50 * time.Minute), End: start.Add(55 * time.Minute), Phase: "break"},
+	}
+
+	totals := sessions.Totals()
+
+	if totals.WorkCount != 2 || totals.WorkDuration != 50*time.Minute {
+		t.Errorf("work totals = %d/%s, want 2/50m", totals.WorkCount, totals.WorkDuration)
+	}
+
+	if totals.BreakCount != 1 || totals.BreakDuration != 5*time.Minute {
+		t.Errorf("break totals = %d/%s, want 1/5m", totals.BreakCount, totals.BreakDuration)
+	}
+}
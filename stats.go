@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/gen2brain/beeep"
+	homedir "github.com/mitchellh/go-homedir"
+
+	"github.com/vkuznetsov/vtimer/sessionlog"
+)
+
+// defaultLogFile returns ~/.vtimer/timer.txt, falling back to a relative
+// path if the home directory can't be resolved.
+func defaultLogFile() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ".vtimer/timer.txt"
+	}
+
+	return filepath.Join(home, ".vtimer", "timer.txt")
+}
+
+// todayTotalsSince loads today's sessions from log that started at or after
+// since, letting "Reset stats" establish a new baseline without touching the
+// log itself. A nil log (logging disabled) yields zero totals; the zero
+// time.Time includes every session recorded today.
+func todayTotalsSince(log *sessionlog.Log, since time.Time) sessionlog.Totals {
+	if log == nil {
+		return sessionlog.Totals{}
+	}
+
+	sessions, err := log.Load()
+	if err != nil {
+		fmt.Println("session log:", err)
+		return sessionlog.Totals{}
+	}
+
+	var kept sessionlog.Sessions
+	for _, s := range sessions.FilterByDate(time.Now()) {
+		if !s.Start.Before(since) {
+			kept = append(kept, s)
+		}
+	}
+
+	return kept.Totals()
+}
+
+// todayStatsTitle formats the stats menu item title from today's totals.
+func todayStatsTitle(totals sessionlog.Totals) string {
+	return fmt.Sprintf("%d work / %s today", totals.WorkCount, totals.WorkDuration.Round(time.Minute))
+}
+
+// showWeeklyStats loads the log, totals the last 7 days and shows the
+// breakdown as a notification.
+func showWeeklyStats(log *sessionlog.Log) {
+	if log == nil {
+		return
+	}
+
+	sessions, err := log.Load()
+	if err != nil {
+		fmt.Println("session log:", err)
+		return
+	}
+
+	now := time.Now()
+
+	var week sessionlog.Sessions
+	for i := 0; i < 7; i++ {
+		week = append(week, sessions.FilterByDate(now.AddDate(0, 0, -i))...)
+	}
+
+	totals := week.Totals()
+	msg := fmt.Sprintf("%d work (%s) / %d breaks (%s) this week",
+		totals.WorkCount, totals.WorkDuration.Round(time.Minute),
+		totals.BreakCount, totals.BreakDuration.Round(time.Minute))
+
+	if err := beeep.Notify("This week", msg, ""); err != nil {
+		fmt.Println("session log:", err)
+	}
+}